@@ -0,0 +1,80 @@
+package certgen
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// CA wraps a certificate authority's certificate and private key so it can issue leaf
+// certificates with Issue, rather than only ever self-signing like genCertPair does.
+type CA struct {
+	Certificate *x509.Certificate
+	PrivateKey  interface{}
+}
+
+// LoadCA parses a CA certificate and its private key, both PEM encoded, and returns a CA
+// that can be used to issue leaf certificates signed by it.
+func LoadCA(certPEM, keyPEM []byte) (*CA, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("failed to decode CA certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %s", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("failed to decode CA private key PEM")
+	}
+	priv, err := parsePrivateKey(keyBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA private key: %s", err)
+	}
+
+	return &CA{Certificate: cert, PrivateKey: priv}, nil
+}
+
+func parsePrivateKey(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key PEM type: %s", block.Type)
+	}
+}
+
+// Issue signs a leaf certificate for cp with the CA's key and certificate. The resulting
+// certificate is always a non-CA end-entity certificate, regardless of cp.IsCA, and carries
+// an AuthorityKeyId pointing back at the CA's SubjectKeyId.
+func (ca *CA) Issue(cp *CertParams) (certPEM, keyPEM []byte, err error) {
+	priv, err := generateKey(cp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key: %s", err)
+	}
+	pub := publicKey(priv)
+
+	template, err := newTemplate(cp, pub)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = false
+	template.BasicConstraintsValid = true
+	template.KeyUsage &^= x509.KeyUsageCertSign
+	template.AuthorityKeyId = ca.Certificate.SubjectKeyId
+
+	derBytes, err := x509.CreateCertificate(cp.rand(), template, ca.Certificate, pub, ca.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %s", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	keyPEM = pem.EncodeToMemory(pemBlockForKey(priv))
+	return certPEM, keyPEM, nil
+}
@@ -8,9 +8,11 @@ package certgen
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha1"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -19,65 +21,102 @@ import (
 	"log"
 	"math/big"
 	"net"
+	"net/url"
 	"os"
 	"strings"
 	"time"
 )
 
-// ECDSACurve represents the supported ECDSA curves for the certificate generation
-type ECDSACurve int
+// KeyType represents the supported private key algorithms for certificate generation
+type KeyType int
 
 const (
-	// P224 to select the P-224 (FIPS 186-3, section D.2.2) elliptic curve
-	P224 ECDSACurve = iota
-	// P256 to select the P-256 (FIPS 186-3, section D.2.3) elliptic curve
-	P256
-	// P384 to select the P-384 (FIPS 186-3, section D.2.4) elliptic curve
-	P384
-	// P521 to select the P-521 (FIPS 186-3, section D.2.5) elliptic curve
-	P521
+	// RSA selects an RSA key, sized by CertParams.RsaBits
+	RSA KeyType = iota
+	// ECDSAP224 selects the P-224 (FIPS 186-3, section D.2.2) elliptic curve
+	ECDSAP224
+	// ECDSAP256 selects the P-256 (FIPS 186-3, section D.2.3) elliptic curve
+	ECDSAP256
+	// ECDSAP384 selects the P-384 (FIPS 186-3, section D.2.4) elliptic curve
+	ECDSAP384
+	// ECDSAP521 selects the P-521 (FIPS 186-3, section D.2.5) elliptic curve
+	ECDSAP521
+	// Ed25519 selects an Ed25519 key
+	Ed25519
 )
 
-func (e ECDSACurve) String() string {
-	switch e {
-	case P224:
+func (k KeyType) String() string {
+	switch k {
+	case RSA:
+		return "RSA"
+	case ECDSAP224:
 		return "P224"
-	case P256:
+	case ECDSAP256:
 		return "P256"
-	case P384:
+	case ECDSAP384:
 		return "P384"
-	case P521:
+	case ECDSAP521:
 		return "P521"
+	case Ed25519:
+		return "Ed25519"
 	default:
 		return ""
 	}
 }
 
-// ECDSACurveFromString maps from a string to the ECDSACurve constant or returns an error
-func ECDSACurveFromString(s string) (ECDSACurve, error) {
+// KeyTypeFromString maps from a string to the KeyType constant or returns an error
+func KeyTypeFromString(s string) (KeyType, error) {
 	switch s {
+	case "RSA":
+		return RSA, nil
 	case "P224":
-		return P224, nil
+		return ECDSAP224, nil
 	case "P256":
-		return P256, nil
+		return ECDSAP256, nil
 	case "P384":
-		return P384, nil
+		return ECDSAP384, nil
 	case "P521":
-		return P521, nil
+		return ECDSAP521, nil
+	case "Ed25519":
+		return Ed25519, nil
 	default:
-		return P224, fmt.Errorf("Invalid or Not supported ECDSA Curve")
+		return RSA, fmt.Errorf("Invalid or Not supported Key Type")
 	}
 }
 
 // CertParams collects all the parameters for generaeting a X509 Certifice
 type CertParams struct {
-	Hosts      string
-	ValidFrom  time.Time
-	ValidFor   time.Duration
-	IsCA       bool
-	Rsa        bool
-	RsaBits    int
-	EcdsaCurve ECDSACurve
+	Hosts          string
+	ValidFrom      time.Time
+	ValidFor       time.Duration
+	IsCA           bool
+	KeyType        KeyType
+	RsaBits        int
+	Subject        pkix.Name
+	EmailAddresses []string
+	URIs           []*url.URL
+
+	// OCSPServer, if non-empty, is embedded in issued leaves as the Authority Information
+	// Access OCSP responder URL(s).
+	OCSPServer []string
+	// CRLDistributionPoints, if non-empty, is embedded in issued leaves as the CRL
+	// Distribution Points extension.
+	CRLDistributionPoints []string
+
+	// Rand is the randomness source used for key generation, serial numbers and certificate
+	// signing. It defaults to crypto/rand.Reader. Only override this with a deterministic
+	// reader (see SeededReader) for tests that need reproducible fixtures - never in production.
+	// A SeededReader only yields reproducible output for KeyType Ed25519; generateKey rejects
+	// it for every other KeyType (see SeededReader's doc comment for why).
+	Rand io.Reader
+}
+
+// rand returns cp.Rand, defaulting to crypto/rand.Reader if it is unset.
+func (cp *CertParams) rand() io.Reader {
+	if cp.Rand != nil {
+		return cp.Rand
+	}
+	return rand.Reader
 }
 
 // NewDefaultParams returns params to generate a certificate with: RSA2048, Valid from now, valid for one year
@@ -86,9 +125,10 @@ func NewDefaultParams() *CertParams {
 	cp.Hosts = "localhost"
 	cp.ValidFrom = time.Now()
 	cp.ValidFor = 365 * 24 * time.Hour
-	cp.Rsa = true
+	cp.KeyType = RSA
 	cp.RsaBits = 2048
-	cp.EcdsaCurve = P256
+	cp.Subject = pkix.Name{Organization: []string{"Acme Co"}}
+	cp.Rand = rand.Reader
 	return cp
 }
 
@@ -102,6 +142,8 @@ func publicKey(priv interface{}) interface{} {
 		return &k.PublicKey
 	case *ecdsa.PrivateKey:
 		return &k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
 	default:
 		return nil
 	}
@@ -118,49 +160,86 @@ func pemBlockForKey(priv interface{}) *pem.Block {
 			os.Exit(2)
 		}
 		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: b}
+	case ed25519.PrivateKey:
+		b, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to marshal Ed25519 private key: %v", err)
+			os.Exit(2)
+		}
+		return &pem.Block{Type: "PRIVATE KEY", Bytes: b}
 	default:
 		return nil
 	}
 }
 
-func genCertPair(cp *CertParams) (interface{}, []byte, error) {
-	var priv interface{}
-	var err error
-	if cp.Rsa {
-		priv, err = rsa.GenerateKey(rand.Reader, cp.RsaBits)
-	} else {
-		switch cp.EcdsaCurve {
-		case P224:
-			priv, err = ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
-		case P256:
-			priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-		case P384:
-			priv, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
-		case P521:
-			priv, err = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
-		}
+func generateKey(cp *CertParams) (interface{}, error) {
+	if _, seeded := cp.Rand.(*seededReader); seeded && cp.KeyType != Ed25519 {
+		return nil, fmt.Errorf("SeededReader only produces reproducible output for KeyType Ed25519, not %s", cp.KeyType)
+	}
+
+	switch cp.KeyType {
+	case RSA:
+		return rsa.GenerateKey(cp.rand(), cp.RsaBits)
+	case ECDSAP224:
+		return ecdsa.GenerateKey(elliptic.P224(), cp.rand())
+	case ECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), cp.rand())
+	case ECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), cp.rand())
+	case ECDSAP521:
+		return ecdsa.GenerateKey(elliptic.P521(), cp.rand())
+	case Ed25519:
+		_, priv, err := ed25519.GenerateKey(cp.rand())
+		return priv, err
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", cp.KeyType)
 	}
+}
+
+// subjectKeyID derives a SubjectKeyId/AuthorityKeyId value from a public key: the SHA-1 hash
+// of the DER encoded SubjectPublicKeyInfo. This differs from RFC 5280 section 4.2.1.2
+// method (1), which hashes only the subjectPublicKey BIT STRING value.
+func subjectKeyID(pub interface{}) ([]byte, error) {
+	b, err := x509.MarshalPKIXPublicKey(pub)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate private key: %s", err)
+		return nil, fmt.Errorf("failed to marshal public key: %s", err)
 	}
+	sum := sha1.Sum(b)
+	return sum[:], nil
+}
 
+// newTemplate builds the x509.Certificate template shared by self-signed and CA-issued
+// certificates, filling in everything that comes from cp and pub (including IsCA and the
+// CertSign key usage when cp.IsCA is set). Callers that sign with a separate CA override the
+// fields that depend on how the certificate is signed (e.g. IsCA, AuthorityKeyId).
+func newTemplate(cp *CertParams, pub interface{}) (*x509.Certificate, error) {
 	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
-	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	serialNumber, err := rand.Int(cp.rand(), serialNumberLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %s", err)
+	}
+
+	skid, err := subjectKeyID(pub)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate serial number: %s", err)
+		return nil, err
 	}
 
-	template := x509.Certificate{
+	template := &x509.Certificate{
 		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Organization: []string{"Acme Co"},
-		},
-		NotBefore: cp.ValidFrom,
-		NotAfter:  cp.notAfter(),
+		Subject:      cp.Subject,
+		NotBefore:    cp.ValidFrom,
+		NotAfter:     cp.notAfter(),
 
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
+		SubjectKeyId:          skid,
+
+		EmailAddresses: cp.EmailAddresses,
+		URIs:           cp.URIs,
+
+		OCSPServer:            cp.OCSPServer,
+		CRLDistributionPoints: cp.CRLDistributionPoints,
 	}
 
 	hosts := strings.Split(cp.Hosts, ",")
@@ -177,7 +256,21 @@ func genCertPair(cp *CertParams) (interface{}, []byte, error) {
 		template.KeyUsage |= x509.KeyUsageCertSign
 	}
 
-	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, publicKey(priv), priv)
+	return template, nil
+}
+
+func genCertPair(cp *CertParams) (interface{}, []byte, error) {
+	priv, err := generateKey(cp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate private key: %s", err)
+	}
+
+	template, err := newTemplate(cp, publicKey(priv))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	derBytes, err := x509.CreateCertificate(cp.rand(), template, template, publicKey(priv), priv)
 	if err != nil {
 		return nil, nil, fmt.Errorf("Failed to create certificate: %s", err)
 	}
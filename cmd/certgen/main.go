@@ -5,22 +5,58 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/0x434D53/certgen"
+
+	"crypto/x509/pkix"
 )
 
 var (
-	host       = flag.String("host", "", "Comma-separated hostnames and IPs to generate a certificate for")
-	validFrom  = flag.String("start-date", "", "Creation date formatted as Jan 1 15:04:05 2011")
-	validFor   = flag.Duration("duration", 365*24*time.Hour, "Duration that certificate is valid for")
-	isCA       = flag.Bool("ca", false, "whether this cert should be its own Certificate Authority")
-	rsaBits    = flag.Int("rsa-bits", 2048, "Size of RSA key to generate. Ignored if --ecdsa-curve is set")
-	ecdsaCurve = flag.String("ecdsa-curve", "", "ECDSA curve to use to generate a key. Valid values are P224, P256, P384, P521")
-	certFile   = flag.String("certfile", "cert.pem", "Filename for the Certificate File")
-	keyFile    = flag.String("pemfile", "key.pem", "Filename for the Key File")
+	host      = flag.String("host", "", "Comma-separated hostnames and IPs to generate a certificate for")
+	validFrom = flag.String("start-date", "", "Creation date formatted as Jan 1 15:04:05 2011")
+	validFor  = flag.Duration("duration", 365*24*time.Hour, "Duration that certificate is valid for")
+	isCA      = flag.Bool("ca", false, "whether this cert should be its own Certificate Authority")
+	rsaBits   = flag.Int("rsa-bits", 2048, "Size of RSA key to generate. Ignored unless --key-type is RSA")
+	keyType   = flag.String("key-type", "RSA", "Key type to generate. Valid values are RSA, P224, P256, P384, P521, Ed25519")
+	subject   = flag.String("subject", "O=Acme Co", "Subject DN for the certificate, e.g. CN=foo,O=bar,OU=baz")
+	certFile  = flag.String("certfile", "cert.pem", "Filename for the Certificate File")
+	keyFile   = flag.String("pemfile", "key.pem", "Filename for the Key File")
 )
 
+// parseSubject parses a comma-separated DN string such as "CN=foo,O=bar,OU=baz" into a pkix.Name.
+func parseSubject(s string) (pkix.Name, error) {
+	var name pkix.Name
+	if len(s) == 0 {
+		return name, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return name, fmt.Errorf("invalid subject component %q, expected KEY=VALUE", part)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch strings.ToUpper(key) {
+		case "CN":
+			name.CommonName = value
+		case "O":
+			name.Organization = append(name.Organization, value)
+		case "OU":
+			name.OrganizationalUnit = append(name.OrganizationalUnit, value)
+		case "C":
+			name.Country = append(name.Country, value)
+		case "L":
+			name.Locality = append(name.Locality, value)
+		case "ST":
+			name.Province = append(name.Province, value)
+		default:
+			return name, fmt.Errorf("unsupported subject component %q", key)
+		}
+	}
+	return name, nil
+}
+
 func main() {
 	flag.Parse()
 
@@ -31,14 +67,18 @@ func main() {
 	cp := &certgen.CertParams{}
 	cp.Hosts = *host
 
-	ecdsa, err := certgen.ECDSACurveFromString(*ecdsaCurve)
+	kt, err := certgen.KeyTypeFromString(*keyType)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse key type: %s\n", err)
+		os.Exit(1)
+	}
+	cp.KeyType = kt
+	cp.RsaBits = *rsaBits
 
+	cp.Subject, err = parseSubject(*subject)
 	if err != nil {
-		cp.Rsa = true
-		cp.RsaBits = *rsaBits
-	} else {
-		cp.Rsa = false
-		cp.EcdsaCurve = ecdsa
+		fmt.Fprintf(os.Stderr, "Failed to parse subject: %s\n", err)
+		os.Exit(1)
 	}
 
 	if len(*validFrom) == 0 {
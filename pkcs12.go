@@ -0,0 +1,69 @@
+package certgen
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// GenerateToPKCS12 generates a self-signed certificate and private key from cp and encodes
+// them into a password protected PKCS#12 bundle, with the certificate itself included as the
+// CA chain entry. This is the format most non-Go TLS stacks (Java keystores, Windows, curl on
+// some platforms) expect when importing a self-signed dev certificate.
+//
+// To bundle a CA-issued leaf together with its real issuing CA instead of a self-signed
+// certificate, use (*CA).IssueToPKCS12.
+func GenerateToPKCS12(cp *CertParams, password string) ([]byte, error) {
+	priv, derBytes, err := genCertPair(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generated certificate: %s", err)
+	}
+
+	pfxData, err := pkcs12.Encode(rand.Reader, priv, cert, []*x509.Certificate{cert}, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#12 bundle: %s", err)
+	}
+	return pfxData, nil
+}
+
+// IssueToPKCS12 issues a leaf certificate for cp signed by ca, then encodes the leaf's
+// private key and certificate together with ca's certificate as the CA chain entry into a
+// password protected PKCS#12 bundle.
+func (ca *CA) IssueToPKCS12(cp *CertParams, password string) ([]byte, error) {
+	certPEM, keyPEM, err := ca.Issue(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("failed to decode issued certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse issued certificate: %s", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("failed to decode issued private key PEM")
+	}
+	priv, err := parsePrivateKey(keyBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse issued private key: %s", err)
+	}
+
+	pfxData, err := pkcs12.Encode(rand.Reader, priv, cert, []*x509.Certificate{ca.Certificate}, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#12 bundle: %s", err)
+	}
+	return pfxData, nil
+}
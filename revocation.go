@@ -0,0 +1,49 @@
+package certgen
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// SignOCSPResponse builds and signs an OCSP response for cert, with the given status
+// (ocsp.Good, ocsp.Revoked or ocsp.Unknown). revokedAt is only used when status is
+// ocsp.Revoked. The CA itself is used as the OCSP responder.
+func (ca *CA) SignOCSPResponse(cert *x509.Certificate, status int, revokedAt time.Time) ([]byte, error) {
+	signer, ok := ca.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("CA private key does not implement crypto.Signer")
+	}
+
+	template := ocsp.Response{
+		Status:       status,
+		SerialNumber: cert.SerialNumber,
+		ThisUpdate:   time.Now(),
+		NextUpdate:   time.Now().Add(7 * 24 * time.Hour),
+	}
+	if status == ocsp.Revoked {
+		template.RevokedAt = revokedAt
+		template.RevocationReason = ocsp.Unspecified
+	}
+
+	resp, err := ocsp.CreateResponse(ca.Certificate, ca.Certificate, template, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign OCSP response: %s", err)
+	}
+	return resp, nil
+}
+
+// GenerateCRL issues a certificate revocation list signed by the CA, listing revoked as of
+// now and valid until expiry.
+func (ca *CA) GenerateCRL(revoked []pkix.RevokedCertificate, now, expiry time.Time) ([]byte, error) {
+	crlBytes, err := ca.Certificate.CreateCRL(rand.Reader, ca.PrivateKey, revoked, now, expiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CRL: %s", err)
+	}
+	return crlBytes, nil
+}
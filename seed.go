@@ -0,0 +1,44 @@
+package certgen
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// SeededReader returns a deterministic io.Reader derived from seed, for use as
+// CertParams.Rand. Reading from it always yields the same byte stream for the same seed.
+//
+// Byte-identical output across runs is only guaranteed for KeyType Ed25519: generateKey
+// rejects any other KeyType when Rand is a SeededReader. crypto/rsa.GenerateKey and
+// crypto/ecdsa.GenerateKey both call into the stdlib's randutil.MaybeReadByte, which decides
+// whether to consume a byte from the reader via a Go "select" with two identical cases - a
+// deliberate stdlib trick to make the decision depend on runtime goroutine scheduling, not on
+// the reader's content. That defeats a seeded reader for RSA/ECDSA key generation, so there is
+// no way to make those key types reproducible through the stdlib GenerateKey entry points.
+//
+// This is for tests only - never use a SeededReader to generate a production key or
+// certificate, since doing so makes the private key and serial number predictable.
+func SeededReader(seed []byte) io.Reader {
+	key := sha256.Sum256(seed)
+	var nonce [chacha20.NonceSize]byte
+	c, err := chacha20.NewUnauthenticatedCipher(key[:], nonce[:])
+	if err != nil {
+		panic(fmt.Sprintf("certgen: failed to create seeded reader: %s", err))
+	}
+	return &seededReader{cipher: c}
+}
+
+type seededReader struct {
+	cipher *chacha20.Cipher
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	r.cipher.XORKeyStream(p, p)
+	return len(p), nil
+}
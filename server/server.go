@@ -3,33 +3,185 @@ package server
 
 import (
 	"crypto/tls"
+	"crypto/x509/pkix"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/0x434D53/certgen"
 )
 
-// ListenAndServeTLS creates a new server like http.ListenAndServeTLS but creates a self-signed certificate on the fly.
-// Warning: Since it's not a trusted certificate chain, the golang http-server will log http2: server: error reading preface... when connecting to the server.
-// If p is nil the Default Parameters (RSA2048, Valid from now for 365 days) will be used for the certificate generation
-func ListenAndServeTLS(addr string, handler http.Handler, p *certgen.CertParams) error {
+// DefaultMaxEntries is the cache size a Server uses when Server.MaxEntries is left at zero.
+const DefaultMaxEntries = 256
+
+// DefaultCertTTL is the lifetime given to minted leaf certificates when Server.CertTTL is left at zero.
+const DefaultCertTTL = 1 * time.Hour
+
+// renewalMargin is how long before a cached certificate's NotAfter it is treated as stale, so
+// clients never get handed a certificate that is about to expire mid-handshake.
+const renewalMargin = 1 * time.Minute
+
+// Server mints a leaf certificate on the fly for every distinct TLS SNI ServerName it sees,
+// signed by an ephemeral CA created once for the lifetime of the Server, and caches the
+// result so repeat handshakes for the same name don't pay for a fresh key and signature.
+// Warning: since the CA is not trusted by anything, clients must call CACertificatePEM and
+// add it to their trust store (or set InsecureSkipVerify) before connecting.
+type Server struct {
+	// MaxEntries bounds how many distinct SNI names are cached at once. Least recently used
+	// entries are evicted first. Zero means DefaultMaxEntries.
+	MaxEntries int
+	// CertTTL is how long a minted leaf certificate stays valid before it is regenerated.
+	// Zero means DefaultCertTTL.
+	CertTTL time.Duration
+
+	ca    *certgen.CA
+	caPEM []byte
+
+	mu    sync.Mutex
+	cache map[string]*cacheEntry
+	order []string
+}
+
+type cacheEntry struct {
+	certificate *tls.Certificate
+	notAfter    time.Time
+}
+
+// NewServer creates a Server backed by a freshly generated, in-memory only CA.
+func NewServer() (*Server, error) {
+	cp := certgen.NewDefaultParams()
+	cp.IsCA = true
+	cp.ValidFor = 10 * 365 * 24 * time.Hour
+	cp.Subject = pkix.Name{CommonName: "certgen ephemeral CA", Organization: []string{"certgen"}}
+
+	caCertPEM, caKeyPEM, err := certgen.GenerateToMemory(cp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ephemeral CA: %s", err)
+	}
+
+	ca, err := certgen.LoadCA(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ephemeral CA: %s", err)
+	}
+
+	return &Server{
+		ca:    ca,
+		caPEM: caCertPEM,
+		cache: make(map[string]*cacheEntry),
+	}, nil
+}
+
+// CACertificatePEM returns the PEM encoded certificate of the ephemeral CA signing every leaf
+// this Server mints, so tests and clients can add it to their trust store.
+func (s *Server) CACertificatePEM() []byte {
+	return s.caPEM
+}
+
+// TLSConfig returns a *tls.Config wired up to mint (or serve from cache) a certificate
+// matching whatever hostname the client requested via SNI. Use this directly when embedding
+// the Server's minting behaviour into something other than the http.Server that
+// ListenAndServeTLS builds, e.g. an httptest.Server in tests.
+func (s *Server) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: s.getCertificate}
+}
+
+// ListenAndServeTLS creates a new server like http.ListenAndServeTLS but, instead of using a
+// single fixed certificate, mints (or serves from cache) a certificate matching whatever
+// hostname the client requested via SNI.
+func (s *Server) ListenAndServeTLS(addr string, handler http.Handler) error {
 	srv := &http.Server{Addr: addr, Handler: handler}
-	if p == nil {
-		p = certgen.NewDefaultParams()
+	srv.TLSConfig = s.TLSConfig()
+	return srv.ListenAndServeTLS("", "")
+}
+
+func (s *Server) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := hello.ServerName
+	if host == "" {
+		host = "localhost"
 	}
-	cert, key, err := certgen.GenerateToMemory(p)
+
+	if entry, ok := s.lookup(host); ok {
+		return entry.certificate, nil
+	}
+
+	// Mint outside the lock: this does a full key generation and signature, and must not
+	// block handshakes for unrelated SNI names while it runs. If two handshakes race to mint
+	// the same host, both succeed and the cache simply keeps whichever store() runs last.
+	entry, err := s.mint(host)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	certificate, err := tls.X509KeyPair(cert, key)
+	s.store(host, entry)
+	return entry.certificate, nil
+}
 
+func (s *Server) lookup(host string) (*cacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[host]
+	if !ok || !time.Now().Before(entry.notAfter.Add(-renewalMargin)) {
+		return nil, false
+	}
+	s.touch(host)
+	return entry, true
+}
+
+func (s *Server) store(host string, entry *cacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache[host] = entry
+	s.touch(host)
+	s.evict()
+}
+
+func (s *Server) mint(host string) (*cacheEntry, error) {
+	cp := certgen.NewDefaultParams()
+	cp.Hosts = host
+	cp.ValidFor = s.certTTL()
+
+	certPEM, keyPEM, err := s.ca.Issue(cp)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to mint certificate for %q: %s", host, err)
 	}
 
-	conf := &tls.Config{}
-	conf.Certificates = append(conf.Certificates, certificate)
-	srv.TLSConfig = conf
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load minted certificate for %q: %s", host, err)
+	}
 
-	return srv.ListenAndServeTLS("", "")
+	return &cacheEntry{certificate: &certificate, notAfter: cp.ValidFrom.Add(cp.ValidFor)}, nil
+}
+
+// touch marks host as the most recently used entry for LRU eviction purposes.
+func (s *Server) touch(host string) {
+	for i, h := range s.order {
+		if h == host {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	s.order = append(s.order, host)
+}
+
+func (s *Server) evict() {
+	max := s.MaxEntries
+	if max <= 0 {
+		max = DefaultMaxEntries
+	}
+	for len(s.order) > max {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		delete(s.cache, oldest)
+	}
+}
+
+func (s *Server) certTTL() time.Duration {
+	if s.CertTTL <= 0 {
+		return DefaultCertTTL
+	}
+	return s.CertTTL
 }
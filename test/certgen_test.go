@@ -1,14 +1,21 @@
 package certgentest
 
 import (
+	"bytes"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/0x434D53/certgen"
+	"github.com/0x434D53/certgen/server"
+	"golang.org/x/crypto/ocsp"
 )
 
 func TestGenCert(t *testing.T) {
@@ -60,3 +67,208 @@ func TestGenCert(t *testing.T) {
 
 	fmt.Printf("%x\n", resp.TLS.CipherSuite)
 }
+
+func TestGenCertKeyTypes(t *testing.T) {
+	for _, kt := range []certgen.KeyType{certgen.RSA, certgen.ECDSAP224, certgen.ECDSAP256, certgen.ECDSAP384, certgen.ECDSAP521, certgen.Ed25519} {
+		cp := certgen.NewDefaultParams()
+		cp.KeyType = kt
+
+		cert, key, err := certgen.GenerateToMemory(cp)
+		if err != nil {
+			t.Fatalf("%s: %v", kt, err)
+		}
+
+		if _, err := tls.X509KeyPair(cert, key); err != nil {
+			t.Fatalf("%s: generated an invalid keypair: %v", kt, err)
+		}
+	}
+}
+
+func TestGenCertSubjectAndSAN(t *testing.T) {
+	cp := certgen.NewDefaultParams()
+	cp.Hosts = "example.com,127.0.0.1"
+	cp.Subject = pkix.Name{CommonName: "example.com", Organization: []string{"Test Org"}}
+	cp.EmailAddresses = []string{"admin@example.com"}
+
+	certPEM, _, err := certgen.GenerateToMemory(cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("failed to decode generated certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cert.Subject.CommonName != "example.com" {
+		t.Fatalf("unexpected CommonName: %q", cert.Subject.CommonName)
+	}
+	if len(cert.Subject.Organization) != 1 || cert.Subject.Organization[0] != "Test Org" {
+		t.Fatalf("unexpected Organization: %v", cert.Subject.Organization)
+	}
+	if len(cert.EmailAddresses) != 1 || cert.EmailAddresses[0] != "admin@example.com" {
+		t.Fatalf("unexpected EmailAddresses: %v", cert.EmailAddresses)
+	}
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != "example.com" {
+		t.Fatalf("unexpected DNSNames: %v", cert.DNSNames)
+	}
+	if len(cert.IPAddresses) != 1 || cert.IPAddresses[0].String() != "127.0.0.1" {
+		t.Fatalf("unexpected IPAddresses: %v", cert.IPAddresses)
+	}
+}
+
+func TestCAIssueToPKCS12(t *testing.T) {
+	caParams := certgen.NewDefaultParams()
+	caParams.IsCA = true
+	caParams.Subject = pkix.Name{CommonName: "Test CA"}
+
+	caCertPEM, caKeyPEM, err := certgen.GenerateToMemory(caParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := certgen.LoadCA(caCertPEM, caKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafParams := certgen.NewDefaultParams()
+	leafParams.Hosts = "leaf.example.com"
+
+	pfxData, err := ca.IssueToPKCS12(leafParams, "s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pfxData) == 0 {
+		t.Fatal("expected a non-empty PKCS#12 bundle")
+	}
+}
+
+func TestServerSNIMinting(t *testing.T) {
+	srv, err := server.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.ServerName)
+	}))
+	ts.TLS = srv.TLSConfig()
+	ts.StartTLS()
+	defer ts.Close()
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(srv.CACertificatePEM()) {
+		t.Fatal("failed to add ephemeral CA certificate to pool")
+	}
+
+	for _, host := range []string{"a.example.com", "b.example.com"} {
+		tr := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, ServerName: host}}
+		client := &http.Client{Transport: tr}
+
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatalf("%s: %v", host, err)
+		}
+
+		msg, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", host, err)
+		}
+
+		if string(msg) != host {
+			t.Fatalf("expected server to mint a certificate for %s, got %s", host, msg)
+		}
+	}
+}
+
+func TestCAOCSPAndCRL(t *testing.T) {
+	caParams := certgen.NewDefaultParams()
+	caParams.IsCA = true
+	caParams.Subject = pkix.Name{CommonName: "Test CA"}
+
+	caCertPEM, caKeyPEM, err := certgen.GenerateToMemory(caParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca, err := certgen.LoadCA(caCertPEM, caKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafCertPEM, _, err := ca.Issue(certgen.NewDefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(leafCertPEM)
+	leafCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ocspResp, err := ca.SignOCSPResponse(leafCert, ocsp.Good, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ocsp.ParseResponse(ocspResp, ca.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Status != ocsp.Good {
+		t.Fatalf("expected ocsp.Good, got %d", parsed.Status)
+	}
+
+	crlBytes, err := ca.GenerateCRL(nil, time.Now(), time.Now().Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(crlBytes) == 0 {
+		t.Fatal("expected a non-empty CRL")
+	}
+}
+
+func TestSeededReaderDeterministicEd25519(t *testing.T) {
+	seed := []byte("fixture-seed")
+	validFrom := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	generate := func() (cert, key []byte) {
+		cp := certgen.NewDefaultParams()
+		cp.KeyType = certgen.Ed25519
+		cp.ValidFrom = validFrom
+		cp.Rand = certgen.SeededReader(seed)
+
+		cert, key, err := certgen.GenerateToMemory(cp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+
+	cert1, key1 := generate()
+	cert2, key2 := generate()
+
+	if !bytes.Equal(cert1, cert2) {
+		t.Fatal("expected identical certificates for the same seed")
+	}
+	if !bytes.Equal(key1, key2) {
+		t.Fatal("expected identical keys for the same seed")
+	}
+}
+
+func TestSeededReaderRejectsNonEd25519(t *testing.T) {
+	cp := certgen.NewDefaultParams()
+	cp.KeyType = certgen.RSA
+	cp.Rand = certgen.SeededReader([]byte("fixture-seed"))
+
+	if _, _, err := certgen.GenerateToMemory(cp); err == nil {
+		t.Fatal("expected an error using a SeededReader with a non-Ed25519 KeyType")
+	}
+}